@@ -5,17 +5,51 @@ import (
 	"strings"
 )
 
+// Supported workload kinds that can be watched and reloaded.
+const (
+	WorkloadDeployment  string = "Deployment"
+	WorkloadStatefulSet string = "StatefulSet"
+	WorkloadDaemonSet   string = "DaemonSet"
+)
+
+// DefaultWorkloads is used when WATCH_WORKLOADS is not set.
+var DefaultWorkloads = []string{WorkloadDeployment, WorkloadStatefulSet, WorkloadDaemonSet}
+
+// Reload modes controlling who owns the watch/keys-to-watch decision.
+const (
+	// ReloadModeOptOut watches every workload unless it explicitly disables
+	// itself via the watch annotation. This is the default.
+	ReloadModeOptOut string = "opt-out"
+	// ReloadModeOptIn only watches workloads that explicitly enable
+	// themselves via the watch annotation.
+	ReloadModeOptIn string = "opt-in"
+)
+
 type Config struct {
 	Namespaces []string
+	Workloads  []string
+	ReloadMode string
 }
 
 func GetConfigOrDie() *Config {
-	cfg := Config{}
+	cfg := Config{
+		Workloads:  DefaultWorkloads,
+		ReloadMode: ReloadModeOptOut,
+	}
 
 	ns, ok := os.LookupEnv("WATCH_NAMESPACE")
 	if ok {
 		cfg.Namespaces = strings.Split(ns, ",")
 	}
 
+	workloads, ok := os.LookupEnv("WATCH_WORKLOADS")
+	if ok {
+		cfg.Workloads = strings.Split(workloads, ",")
+	}
+
+	if mode, ok := os.LookupEnv("RELOAD_MODE"); ok {
+		cfg.ReloadMode = mode
+	}
+
 	return &cfg
 }
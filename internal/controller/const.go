@@ -7,16 +7,32 @@ import (
 )
 
 const (
-	WatchAnnotation           string = "k8s-confsec-reloader.io/watch"
-	KeyWatchAnnotation        string = "k8s-confsec-reloader.io/keys-to-watch"
-	ReloadTimestampAnnotation string = "k8s-confsec-reloader.io/reload-timestamp"
-
-	ConfigMapIndexKey string = "index.deployment.by.configmap"
-	SecretIndexKey    string = "index.deployment.by.secret"
+	WatchAnnotation      string = "k8s-confsec-reloader.io/watch"
+	KeyWatchAnnotation   string = "k8s-confsec-reloader.io/keys-to-watch"
+	ConfigHashAnnotation string = "k8s-confsec-reloader.io/config-hash"
+
+	// RefsAnnotation caches the ConfigMaps/Secrets a workload references, as
+	// "configmap:name" / "secret:name" pairs. It lets the field indexer work
+	// off metadata-only watches instead of requiring the full pod spec to be
+	// cached for every workload in the cluster. It is kept up to date by
+	// WorkloadRefsReconciler, which recomputes it off every workload Create
+	// and every Update that changes its generation or annotations, so it
+	// stays current for workloads created, or whose
+	// refs change, independently of whether a reload has ever touched them.
+	RefsAnnotation string = "k8s-confsec-reloader.io/refs"
+
+	ConfigMapIndexKey string = "index.workload.by.configmap"
+	SecretIndexKey    string = "index.workload.by.secret"
 )
 
 // parseWatch checks if the object is being tracked
 // watch by default when no annotation is set
+//
+// This is the only annotation that can veto tracking outright: the source
+// reconciler's event filter (getFilter) checks this on the source object
+// before a workload's own watch/keys-to-watch annotations ever get a say, so
+// watch: "false" here disables reloads for every workload regardless of what
+// the workload itself asks for.
 func parseWatch(obj client.Object) bool {
 	watch := obj.GetAnnotations()[WatchAnnotation]
 	if watch == "" || watch == "true" {
@@ -28,6 +44,14 @@ func parseWatch(obj client.Object) bool {
 
 // parseKeysToWatch extracts keys to watch from the annotations
 // watch all keys by default when no annotation is set
+//
+// This is a per-object default, not an early-exit filter: reloadWorkloads
+// reads a workload's own keys-to-watch with it, and on a ConfigMap/Secret it
+// only feeds computeWorkloadHash as the fallback used when the workload
+// doesn't set its own keys-to-watch annotation. The source reconciler's event filter
+// (getFilter) deliberately ignores it and hashes full content instead, so a
+// workload-level override still gets evaluated even for a key change the
+// source's own restricted list wouldn't have noticed.
 func parseKeysToWatch(obj client.Object) []string {
 	keys := obj.GetAnnotations()[KeyWatchAnnotation]
 	if keys == "" {
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"hash/fnv"
@@ -8,6 +9,8 @@ import (
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // generateFNVHash generates a fast FNV-1a hash from a given string
@@ -45,3 +48,80 @@ func GetConfigMapHash(configmap *corev1.ConfigMap, keysToWatch []string) string
 
 	return generateFNVHash(strings.Join(values, ";"))
 }
+
+// computeWorkloadHash gathers every ConfigMap and Secret referenced by podSpec
+// and combines their content hashes into a single stable value, so a reload is
+// triggered exactly once per real change and not on every reconcile.
+//
+// keysToWatch, when non-empty, overrides the keys-to-watch annotation on each
+// referenced ConfigMap/Secret - this is how a workload's own keys-to-watch
+// annotation takes precedence over the source's.
+func computeWorkloadHash(ctx context.Context, c client.Client, namespace string, podSpec corev1.PodSpec, keysToWatch []string) (string, error) {
+	var parts []string
+
+	for _, name := range extractConfigMapRefs(podSpec) {
+		configMap := &corev1.ConfigMap{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, configMap); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return "", err
+		}
+
+		keys := keysToWatch
+		if len(keys) == 0 {
+			keys = parseKeysToWatch(configMap)
+		}
+		parts = append(parts, "configmap/"+name+"="+GetConfigMapHash(configMap, keys))
+	}
+
+	for _, name := range extractSecretRefs(podSpec) {
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return "", err
+		}
+
+		keys := keysToWatch
+		if len(keys) == 0 {
+			keys = parseKeysToWatch(secret)
+		}
+		parts = append(parts, "secret/"+name+"="+GetSecretHash(secret, keys))
+	}
+
+	// Sort to ensure consistent ordering regardless of ref discovery order
+	sort.Strings(parts)
+
+	return generateFNVHash(strings.Join(parts, ";")), nil
+}
+
+// GetSecretHash computes a stable hash for Secret data (optimized)
+func GetSecretHash(secret *corev1.Secret, keysToWatch []string) string {
+	var values []string
+
+	// Filter and process only specified keys (if provided)
+	if len(keysToWatch) > 0 {
+		for _, key := range keysToWatch {
+			if val, exists := secret.Data[key]; exists {
+				values = append(values, key+"="+base64.StdEncoding.EncodeToString(val))
+			}
+			if val, exists := secret.StringData[key]; exists {
+				values = append(values, key+"="+val)
+			}
+		}
+	} else {
+		for k, v := range secret.Data {
+			values = append(values, k+"="+base64.StdEncoding.EncodeToString(v))
+		}
+		for k, v := range secret.StringData {
+			values = append(values, k+"="+v)
+		}
+	}
+
+	// Sort to ensure consistent ordering (maps are unordered)
+	sort.Strings(values)
+
+	return generateFNVHash(strings.Join(values, ";"))
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// reloadsTotal counts every workload reload actually triggered, broken
+	// down by the workload that was reloaded and the source that caused it.
+	reloadsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "confsec_reloader_reloads_total",
+			Help: "Total number of workload reloads triggered by k8s-confsec-reloader.",
+		},
+		[]string{"namespace", "workload_kind", "workload", "source_kind", "source_name"},
+	)
+
+	// reloadErrorsTotal counts failures while triggering a workload reload.
+	reloadErrorsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "confsec_reloader_reload_errors_total",
+			Help: "Total number of errors encountered while triggering workload reloads.",
+		},
+	)
+
+	// hashMismatchTotal counts how often a watched ConfigMap/Secret's content
+	// hash actually changed between reconciles.
+	hashMismatchTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "confsec_reloader_hash_mismatch_total",
+			Help: "Total number of times a tracked ConfigMap/Secret's content hash changed.",
+		},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(reloadsTotal, reloadErrorsTotal, hashMismatchTotal)
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// extractConfigMapRefs collects the names of ConfigMaps referenced by a Pod
+// spec via volumes, env and envFrom across containers and init containers.
+// Names are sorted, since they are deduplicated through a map, so that
+// RefsAnnotation comes out byte-identical across calls when the actual refs
+// haven't changed (WorkloadRefsReconciler compares it as a plain string).
+func extractConfigMapRefs(spec corev1.PodSpec) []string {
+	refs := make(map[string]struct{})
+
+	for _, vol := range spec.Volumes {
+		if vol.ConfigMap != nil {
+			refs[vol.ConfigMap.Name] = struct{}{}
+		}
+	}
+
+	collect := func(containers []corev1.Container) {
+		for _, container := range containers {
+			for _, env := range container.Env {
+				if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+					refs[env.ValueFrom.ConfigMapKeyRef.Name] = struct{}{}
+				}
+			}
+			for _, envFrom := range container.EnvFrom {
+				if envFrom.ConfigMapRef != nil {
+					refs[envFrom.ConfigMapRef.Name] = struct{}{}
+				}
+			}
+		}
+	}
+	collect(spec.Containers)
+	collect(spec.InitContainers)
+
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// extractSecretRefs collects the names of Secrets referenced by a Pod spec via
+// volumes, env and envFrom across containers and init containers. Names are
+// sorted for the same reason as extractConfigMapRefs.
+func extractSecretRefs(spec corev1.PodSpec) []string {
+	refs := make(map[string]struct{})
+
+	for _, vol := range spec.Volumes {
+		if vol.Secret != nil {
+			refs[vol.Secret.SecretName] = struct{}{}
+		}
+	}
+
+	collect := func(containers []corev1.Container) {
+		for _, container := range containers {
+			for _, env := range container.Env {
+				if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+					refs[env.ValueFrom.SecretKeyRef.Name] = struct{}{}
+				}
+			}
+			for _, envFrom := range container.EnvFrom {
+				if envFrom.SecretRef != nil {
+					refs[envFrom.SecretRef.Name] = struct{}{}
+				}
+			}
+		}
+	}
+	collect(spec.Containers)
+	collect(spec.InitContainers)
+
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// parseRefsAnnotation extracts the names referenced under the given source
+// kind prefix from a workload's RefsAnnotation value.
+func parseRefsAnnotation(value, sourceKind string) []string {
+	if value == "" {
+		return nil
+	}
+
+	var names []string
+	for _, ref := range strings.Split(value, ",") {
+		kind, name, ok := strings.Cut(ref, ":")
+		if !ok || kind != sourceKind {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// mergeRefsAnnotation replaces the entries for sourceKind in an existing
+// RefsAnnotation value with names, leaving entries for every other source
+// kind untouched - so the ConfigMap and Secret reconcilers can each refresh
+// their own half of the cache without clobbering the other's.
+func mergeRefsAnnotation(existing, sourceKind string, names []string) string {
+	var kept []string
+	if existing != "" {
+		for _, ref := range strings.Split(existing, ",") {
+			kind, _, ok := strings.Cut(ref, ":")
+			if ok && kind == sourceKind {
+				continue
+			}
+			kept = append(kept, ref)
+		}
+	}
+
+	for _, name := range names {
+		kept = append(kept, sourceKind+":"+name)
+	}
+
+	return strings.Join(kept, ",")
+}
@@ -0,0 +1,270 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/BackAged/k8s-confsec-reloader/internal/config"
+)
+
+// ResourceReconciler reconciles a tracked source object (ConfigMap, Secret,
+// ...) of type T and triggers a reload of any workload that references it.
+// ConfigMapReconciler and SecretReconciler are thin constructors around this
+// type selecting the hash function and ref extractor for their resource, so
+// adding a new tracked source is a matter of writing one more constructor.
+type ResourceReconciler[T client.Object] struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Config   *config.Config
+	Recorder record.EventRecorder
+
+	// APIReader is an uncached, direct-to-apiserver reader used for the
+	// one-off full fetch of a workload object in reloadWorkloads, once it is
+	// known to need a reload. Reading it through the cached Client would make
+	// the manager stand up a full-spec informer for that GVK on first use,
+	// exactly the per-workload cache the metadata-only indexer exists to
+	// avoid.
+	APIReader client.Reader
+
+	// Kind is the human-readable name of the tracked resource, used in log
+	// messages (e.g. "ConfigMap").
+	Kind string
+	// IndexKey is the field index workloads are queried by.
+	IndexKey string
+	// NewObj returns an empty T to Get the tracked resource into.
+	NewObj func() T
+	// HashFunc computes a stable content hash for the tracked resource.
+	HashFunc func(obj T, keysToWatch []string) string
+	// ExtractRefs collects the names of tracked resources of this kind
+	// referenced by a Pod spec.
+	ExtractRefs func(spec corev1.PodSpec) []string
+}
+
+func (r *ResourceReconciler[T]) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	// Fetch the resource that triggered the event
+	obj := r.NewObj()
+	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		if errors.IsNotFound(err) {
+			// Resource was deleted, no action needed
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to fetch "+r.Kind)
+		return ctrl.Result{}, err
+	}
+
+	for _, kind := range enabledWorkloadKinds(r.Config) {
+		if err := r.reloadWorkloads(ctx, req.Namespace, kind, obj.GetName()); err != nil {
+			log.Error(err, "Failed to reload workloads", "sourceKind", r.Kind, "source", obj.GetName(), "workloadKind", kind)
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// listMatchingWorkloads lists, as metadata only, the workloads of the given
+// kind that reference the named source object and opt into being watched.
+// Listing metadata rather than full objects keeps the controller's cache
+// from having to hold every workload's pod spec in memory.
+func (r *ResourceReconciler[T]) listMatchingWorkloads(ctx context.Context, namespace, kind, sourceName string) ([]metav1.PartialObjectMetadata, error) {
+	list := &metav1.PartialObjectMetadataList{}
+	list.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind(kind + "List"))
+
+	if err := r.List(ctx, list, client.InNamespace(namespace), client.MatchingFields{r.IndexKey: sourceName}); err != nil {
+		return nil, err
+	}
+
+	matches := make([]metav1.PartialObjectMetadata, 0, len(list.Items))
+	for _, item := range list.Items {
+		if shouldReloadWorkload(&item, r.Config) {
+			matches = append(matches, item)
+		}
+	}
+
+	return matches, nil
+}
+
+// reloadWorkloads finds workloads of the given kind that reference the named
+// source object and triggers a reload for each one that opts into being
+// watched, fetching each workload's full object, through the uncached
+// APIReader, only once it is known to need a reload.
+func (r *ResourceReconciler[T]) reloadWorkloads(ctx context.Context, namespace, kind, sourceName string) error {
+	log := log.FromContext(ctx)
+
+	matches, err := r.listMatchingWorkloads(ctx, namespace, kind, sourceName)
+	if err != nil {
+		return err
+	}
+
+	for _, match := range matches {
+		key := client.ObjectKey{Namespace: match.Namespace, Name: match.Name}
+
+		obj := newWorkloadObject(kind)
+		if obj == nil {
+			continue
+		}
+
+		triggerErr := r.APIReader.Get(ctx, key, obj)
+		if triggerErr == nil {
+			triggerErr = r.TriggerReload(ctx, obj, kind, parseKeysToWatch(obj), sourceName)
+		}
+
+		if triggerErr != nil {
+			if errors.IsNotFound(triggerErr) {
+				continue
+			}
+			reloadErrorsTotal.Inc()
+			return triggerErr
+		}
+
+		log.Info("Triggered reload for workload", "workloadKind", kind, "workload", key.Name)
+	}
+
+	return nil
+}
+
+// recordReload records the reloads_total metric and, if an EventRecorder is
+// wired in, emits a ReloadTriggered event on the reloaded workload.
+func (r *ResourceReconciler[T]) recordReload(obj client.Object, workloadKind, sourceName, oldHash, newHash string) {
+	reloadsTotal.WithLabelValues(obj.GetNamespace(), workloadKind, obj.GetName(), r.Kind, sourceName).Inc()
+
+	if r.Recorder != nil {
+		r.Recorder.Eventf(obj, corev1.EventTypeNormal, "ReloadTriggered",
+			"Reloaded due to %s %q change (hash %s -> %s)", r.Kind, sourceName, oldHash, newHash)
+	}
+}
+
+// TriggerReload patches the workload's pod template with the current config
+// hash, which only changes the pod template (and so triggers a rollout) when
+// a referenced ConfigMap/Secret actually changed. It works across
+// Deployments, StatefulSets and DaemonSets alike via podTemplateSpec, since
+// the three only differ in where they keep their PodTemplateSpec.
+func (r *ResourceReconciler[T]) TriggerReload(ctx context.Context, obj client.Object, workloadKind string, keysToWatch []string, sourceName string) error {
+	template := podTemplateSpec(obj)
+	if template == nil {
+		return fmt.Errorf("unsupported workload kind %T", obj)
+	}
+
+	hash, err := computeWorkloadHash(ctx, r.Client, obj.GetNamespace(), template.Spec, keysToWatch)
+	if err != nil {
+		return err
+	}
+
+	oldHash := template.Annotations[ConfigHashAnnotation]
+	if oldHash == hash {
+		return nil
+	}
+
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[ConfigHashAnnotation] = hash
+
+	// RefsAnnotation itself is WorkloadRefsReconciler's to maintain, not
+	// TriggerReload's: it runs off workload Create events and Updates that
+	// change generation or annotations, not just
+	// ones that happened to reload, so it is the one place that keeps the
+	// annotation current for workloads this reload never touches.
+
+	// Use Patch instead of Update
+	if err := r.Patch(ctx, obj, patch); err != nil {
+		return err
+	}
+
+	r.recordReload(obj, workloadKind, sourceName, oldHash, hash)
+
+	return nil
+}
+
+// indexRefs indexes workloads by the tracked resources they reference. It
+// reads RefsAnnotation rather than the pod spec, so it works against
+// metadata-only workload objects and never forces the cache to hold a full
+// spec just to build the index. RefsAnnotation is kept up to date by
+// WorkloadRefsReconciler, which recomputes it off every workload Create and
+// every Update that changes its generation or annotations, rather than only
+// as a side effect of a reload.
+func (r *ResourceReconciler[T]) indexRefs(obj client.Object) []string {
+	return parseRefsAnnotation(obj.GetAnnotations()[RefsAnnotation], strings.ToLower(r.Kind))
+}
+
+// getFilter processes events based on the following:
+//   - the tracked resource's data changed
+//   - tracking is not disabled on the tracked resource
+func (r *ResourceReconciler[T]) getFilter() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldObj, okOld := e.ObjectOld.(T)
+			newObj, okNew := e.ObjectNew.(T)
+
+			if !okOld || !okNew {
+				return false
+			}
+
+			if !parseWatch(newObj) {
+				return false
+			}
+
+			// Compare full content here, not the source's own keys-to-watch:
+			// a workload can watch a narrower (or different) set of keys via
+			// its own keys-to-watch annotation (see computeWorkloadHash), and
+			// that override must still get a chance to fire downstream even
+			// for a key change the source's own annotation would have
+			// filtered out.
+			changed := r.HashFunc(oldObj, nil) != r.HashFunc(newObj, nil)
+			if changed {
+				hashMismatchTotal.Inc()
+			}
+
+			return changed
+		},
+		DeleteFunc: func(event.DeleteEvent) bool {
+			return false
+		},
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ResourceReconciler[T]) SetupWithManager(mgr ctrl.Manager) error {
+	// Index each enabled workload kind, as metadata only, by the tracked
+	// resources it references.
+	for _, kind := range enabledWorkloadKinds(r.Config) {
+		obj := newWorkloadMetadataObject(kind)
+		if obj == nil {
+			continue
+		}
+
+		if err := mgr.GetFieldIndexer().IndexField(
+			context.Background(),
+			obj,
+			r.IndexKey,
+			r.indexRefs,
+		); err != nil {
+			return err
+		}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(r.NewObj()).
+		WithEventFilter(r.getFilter()).
+		Complete(r)
+}
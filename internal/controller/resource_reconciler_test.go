@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/BackAged/k8s-confsec-reloader/internal/config"
+)
+
+// TestWorkloadRefsReconciler_Reconcile verifies that a Deployment that
+// references a watched ConfigMap gets
+// RefsAnnotation populated on reconcile, so the metadata-only field indexer
+// can find it - regardless of whether it has ever been reloaded, and without
+// requiring a one-time startup pass to have caught it.
+func TestWorkloadRefsReconciler_Reconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme: %v", err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme: %v", err)
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: "app",
+						EnvFrom: []corev1.EnvFromSource{
+							{ConfigMapRef: &corev1.ConfigMapEnvSource{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"},
+							}},
+							{ConfigMapRef: &corev1.ConfigMapEnvSource{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "shared-config"},
+							}},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(deployment).Build()
+
+	r := NewWorkloadRefsReconciler(fakeClient, fakeClient, nil, config.WorkloadDeployment)
+
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployment)}
+
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	got := &appsv1.Deployment{}
+	if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(deployment), got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	refs := parseRefsAnnotation(got.GetAnnotations()[RefsAnnotation], "configmap")
+	if len(refs) != 2 || refs[0] != "app-config" || refs[1] != "shared-config" {
+		t.Fatalf("refs after reconcile = %v, want [app-config shared-config]", refs)
+	}
+
+	// Reconciling again, with refs already current, must be a no-op patch -
+	// including when there's more than one ref of the same kind, where a
+	// naive rebuild-and-compare could churn on map iteration order alone.
+	resourceVersion := got.ResourceVersion
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile (second run): %v", err)
+	}
+
+	got2 := &appsv1.Deployment{}
+	if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(deployment), got2); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if got2.ResourceVersion != resourceVersion {
+		t.Fatalf("Reconcile re-patched an already up-to-date workload: resourceVersion %s -> %s", resourceVersion, got2.ResourceVersion)
+	}
+
+	// A new ref added after the fact (e.g. a workload created or edited after
+	// manager startup) must be picked up on the next reconcile too, not just
+	// the first one.
+	updated := got2.DeepCopy()
+	updated.Spec.Template.Spec.Containers[0].EnvFrom = append(updated.Spec.Template.Spec.Containers[0].EnvFrom, corev1.EnvFromSource{
+		SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "app-secret"}},
+	})
+	if err := fakeClient.Update(context.Background(), updated); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile (after spec change): %v", err)
+	}
+
+	got3 := &appsv1.Deployment{}
+	if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(deployment), got3); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if refs := parseRefsAnnotation(got3.GetAnnotations()[RefsAnnotation], "secret"); len(refs) != 1 || refs[0] != "app-secret" {
+		t.Fatalf("refs after spec change = %v, want [app-secret]", refs)
+	}
+	if refs := parseRefsAnnotation(got3.GetAnnotations()[RefsAnnotation], "configmap"); len(refs) != 2 || refs[0] != "app-config" || refs[1] != "shared-config" {
+		t.Fatalf("configmap refs after spec change = %v, want [app-config shared-config]", refs)
+	}
+}
+
+// TestWorkloadRefsReconciler_SkipsOptedOut verifies a workload that disabled
+// itself via WatchAnnotation never gets RefsAnnotation written - matching
+// shouldReloadWorkload's verdict on the reload path, rather than indexing
+// every workload regardless of whether it opted in.
+func TestWorkloadRefsReconciler_SkipsOptedOut(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme: %v", err)
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "app",
+			Namespace:   "default",
+			Annotations: map[string]string{WatchAnnotation: "false"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: "app",
+						EnvFrom: []corev1.EnvFromSource{{
+							ConfigMapRef: &corev1.ConfigMapEnvSource{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "app-config"},
+							},
+						}},
+					}},
+				},
+			},
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(deployment).Build()
+	r := NewWorkloadRefsReconciler(fakeClient, fakeClient, nil, config.WorkloadDeployment)
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployment)}); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	got := &appsv1.Deployment{}
+	if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(deployment), got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if _, ok := got.GetAnnotations()[RefsAnnotation]; ok {
+		t.Fatalf("RefsAnnotation set on an opted-out workload: %v", got.GetAnnotations())
+	}
+}
+
+// TestRefsRelevantChange verifies the predicate that gates WorkloadRefsReconciler's
+// Update events: a pod-spec change (generation bump) and an annotation-only
+// edit (e.g. flipping WatchAnnotation) both matter, pure status churn doesn't.
+func TestRefsRelevantChange(t *testing.T) {
+	base := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Generation: 1, Annotations: map[string]string{"a": "b"}},
+	}
+
+	tests := map[string]struct {
+		newObj *appsv1.Deployment
+		want   bool
+	}{
+		"generation bump": {
+			newObj: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Generation: 2, Annotations: map[string]string{"a": "b"}}},
+			want:   true,
+		},
+		"annotation edit": {
+			newObj: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Generation: 1, Annotations: map[string]string{"a": "c"}}},
+			want:   true,
+		},
+		"status-only churn": {
+			newObj: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Generation: 1, Annotations: map[string]string{"a": "b"}}, Status: appsv1.DeploymentStatus{ReadyReplicas: 3}},
+			want:   false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := refsRelevantChange(event.UpdateEvent{ObjectOld: base, ObjectNew: tc.newObj})
+			if got != tc.want {
+				t.Fatalf("refsRelevantChange() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/BackAged/k8s-confsec-reloader/internal/config"
+)
+
+// WorkloadRefsReconciler keeps RefsAnnotation up to date for every workload of
+// a single kind, so the ConfigMap/Secret reconcilers' metadata-only field
+// indexers always have something current to query. It replaces the old
+// backfill-once-at-startup approach: that left a workload invisible to the
+// index forever if it was created, or grew a new ConfigMap/Secret reference,
+// without otherwise triggering a reload. Reacting to every Create of the
+// workload and to every Update of its generation or annotations instead
+// keeps the annotation current on an
+// ongoing basis, while builder.OnlyMetadata still keeps the cache from having
+// to hold a full pod spec per workload.
+type WorkloadRefsReconciler struct {
+	client.Client
+
+	// APIReader is an uncached, direct-to-apiserver reader used to fetch the
+	// workload's full object once its metadata event is known. Reading it
+	// through the cached Client would force the manager to stand up a
+	// full-spec informer for this GVK, exactly what the metadata-only watch
+	// exists to avoid.
+	APIReader client.Reader
+
+	// Config gates which workloads get their refs tracked at all, the same
+	// way it gates which workloads get reloaded (see shouldReloadWorkload):
+	// a workload that opted out of being watched has no business in the
+	// index in the first place.
+	Config *config.Config
+
+	// Kind is the workload kind this reconciler recomputes refs for, e.g.
+	// config.WorkloadDeployment.
+	Kind string
+}
+
+// NewWorkloadRefsReconciler builds a WorkloadRefsReconciler for a single
+// workload kind.
+func NewWorkloadRefsReconciler(c client.Client, apiReader client.Reader, cfg *config.Config, kind string) *WorkloadRefsReconciler {
+	return &WorkloadRefsReconciler{
+		Client:    c,
+		APIReader: apiReader,
+		Config:    cfg,
+		Kind:      kind,
+	}
+}
+
+func (r *WorkloadRefsReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	obj := newWorkloadObject(r.Kind)
+	if obj == nil {
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.APIReader.Get(ctx, req.NamespacedName, obj); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to fetch workload", "workloadKind", r.Kind)
+		return ctrl.Result{}, err
+	}
+
+	template := podTemplateSpec(obj)
+	if template == nil {
+		return ctrl.Result{}, nil
+	}
+
+	if !shouldReloadWorkload(obj, r.Config) {
+		return ctrl.Result{}, nil
+	}
+
+	existing := obj.GetAnnotations()[RefsAnnotation]
+	updated := mergeRefsAnnotation(existing, "configmap", extractConfigMapRefs(template.Spec))
+	updated = mergeRefsAnnotation(updated, "secret", extractSecretRefs(template.Spec))
+
+	if updated == existing {
+		return ctrl.Result{}, nil
+	}
+
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[RefsAnnotation] = updated
+	obj.SetAnnotations(annotations)
+
+	if err := r.Patch(ctx, obj, patch); err != nil {
+		log.Error(err, "Failed to refresh refs annotation", "workloadKind", r.Kind, "workload", obj.GetName())
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// refsRelevantChange reports whether an update could change either the refs
+// Reconcile would compute (a generation bump, meaning the pod spec changed)
+// or whether shouldReloadWorkload's verdict on the workload could change (an
+// annotation edit, e.g. flipping WatchAnnotation). Filtering on generation
+// alone would miss the latter: annotation-only edits never bump generation,
+// so a workload opted back in by annotation alone would otherwise never see
+// another event to (re)populate its refs.
+func refsRelevantChange(e event.UpdateEvent) bool {
+	if e.ObjectNew.GetGeneration() != e.ObjectOld.GetGeneration() {
+		return true
+	}
+
+	return !reflect.DeepEqual(e.ObjectOld.GetAnnotations(), e.ObjectNew.GetAnnotations())
+}
+
+// SetupWithManager sets up the controller with the Manager. It watches the
+// workload kind as metadata only via builder.OnlyMetadata, reacting to every
+// Create and to Updates that could change its refs or its opt-in status, so
+// a workload's refs get recomputed whether it is brand new, had its pod spec
+// changed, or had its watch annotation flipped - without otherwise triggering
+// a reload. This skips pure status churn (e.g. an HPA-driven scale or a
+// rollout progressing), which bumps resourceVersion but touches neither
+// generation nor annotations.
+func (r *WorkloadRefsReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	obj := newWorkloadObject(r.Kind)
+	if obj == nil {
+		return nil
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(obj, builder.OnlyMetadata).
+		WithEventFilter(predicate.Funcs{
+			UpdateFunc: refsRelevantChange,
+		}).
+		Complete(r)
+}
+
+// SetupWorkloadRefsReconcilers registers one WorkloadRefsReconciler per
+// enabled workload kind, so every kind's refs stay current independently of
+// which (if any) ConfigMap/Secret reload has touched it so far.
+func SetupWorkloadRefsReconcilers(mgr ctrl.Manager, apiReader client.Reader, cfg *config.Config) error {
+	for _, kind := range enabledWorkloadKinds(cfg) {
+		if err := NewWorkloadRefsReconciler(mgr.GetClient(), apiReader, cfg, kind).SetupWithManager(mgr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/BackAged/k8s-confsec-reloader/internal/config"
+)
+
+// enabledWorkloadKinds returns the workload kinds a reconciler should index
+// and reload, defaulting to every supported kind when no config is wired in.
+func enabledWorkloadKinds(cfg *config.Config) []string {
+	if cfg == nil || len(cfg.Workloads) == 0 {
+		return config.DefaultWorkloads
+	}
+
+	return cfg.Workloads
+}
+
+// newWorkloadMetadataObject returns an empty PartialObjectMetadata carrying
+// the GVK for the given workload kind, or nil if the kind is not a supported
+// reload target. The field indexer is registered against this instead of a
+// full typed object, so the cache only has to hold workload metadata rather
+// than a full pod spec per workload.
+func newWorkloadMetadataObject(kind string) *metav1.PartialObjectMetadata {
+	switch kind {
+	case config.WorkloadDeployment, config.WorkloadStatefulSet, config.WorkloadDaemonSet:
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind(kind))
+		return obj
+	default:
+		return nil
+	}
+}
+
+// newWorkloadObject returns an empty, fully-typed object for the given
+// workload kind, or nil if the kind is not a supported reload target. It is
+// used for the one-off full fetches that reloadWorkloads and
+// WorkloadRefsReconciler do once a workload is known to need one, as opposed
+// to the PartialObjectMetadata used everywhere else.
+func newWorkloadObject(kind string) client.Object {
+	switch kind {
+	case config.WorkloadDeployment:
+		return &appsv1.Deployment{}
+	case config.WorkloadStatefulSet:
+		return &appsv1.StatefulSet{}
+	case config.WorkloadDaemonSet:
+		return &appsv1.DaemonSet{}
+	default:
+		return nil
+	}
+}
+
+// podTemplateSpec returns the mutable pod template embedded in a
+// Deployment/StatefulSet/DaemonSet, or nil for any other object. It is the
+// one place that knows the three workload kinds differ only in where they
+// keep their PodTemplateSpec, so callers can patch the template/compute its
+// hash/extract its refs without a kind-specific code path of their own.
+func podTemplateSpec(obj client.Object) *corev1.PodTemplateSpec {
+	switch w := obj.(type) {
+	case *appsv1.Deployment:
+		return &w.Spec.Template
+	case *appsv1.StatefulSet:
+		return &w.Spec.Template
+	case *appsv1.DaemonSet:
+		return &w.Spec.Template
+	default:
+		return nil
+	}
+}
+
+// reloadMode returns the configured reload mode, defaulting to opt-out when
+// no config is wired in.
+func reloadMode(cfg *config.Config) string {
+	if cfg == nil || cfg.ReloadMode == "" {
+		return config.ReloadModeOptOut
+	}
+
+	return cfg.ReloadMode
+}
+
+// shouldReloadWorkload decides whether a workload should be reloaded, based
+// on its own watch annotation and the configured reload mode. In opt-out mode
+// (the default) a workload is watched unless it explicitly disables itself;
+// in opt-in mode it is skipped unless it explicitly enables itself. This puts
+// the decision in the hands of the workload owner rather than the owner of
+// the ConfigMap/Secret it consumes.
+func shouldReloadWorkload(obj client.Object, cfg *config.Config) bool {
+	val, exists := obj.GetAnnotations()[WatchAnnotation]
+	if !exists {
+		return reloadMode(cfg) != config.ReloadModeOptIn
+	}
+
+	return val == "true"
+}